@@ -0,0 +1,156 @@
+// Package client is a typed GNAME API client used internally by the
+// top-level gname package. It owns request signing, response decoding, and
+// the GNAME error model, so the provider package can stay a thin adapter
+// onto libdns interfaces.
+package client
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	gojoin "github.com/yuchenfw/go-join"
+)
+
+// BaseURL is the default GNAME API endpoint.
+const BaseURL = "https://api.gname.com"
+
+// APIError is returned when GNAME responds with a non-success code. Callers
+// can errors.As against it to distinguish GNAME error conditions (e.g.
+// record-not-found vs. auth-failure) instead of string-matching wrapped
+// errors.
+type APIError struct {
+	Code int
+	Msg  string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("gname API error %d: %s", e.Code, e.Msg)
+}
+
+// Client is a typed GNAME API client.
+type Client struct {
+	// APPID is the application ID for GNAME API authentication.
+	APPID string
+
+	// APPKey is the application key for GNAME API authentication.
+	APPKey string
+
+	// BaseURL is the GNAME API endpoint. Defaults to BaseURL above; only
+	// overridden by tests, to point at a fake server.
+	BaseURL string
+
+	// HTTPClient performs the underlying HTTP requests.
+	HTTPClient *http.Client
+
+	// cstLoc is GNAME's signing timezone (UTC+8), cached so every request
+	// doesn't pay for a tzdata lookup that can fail on minimal containers.
+	cstLoc *time.Location
+}
+
+// NewClient returns a Client ready to make GNAME API requests.
+func NewClient(appID, appKey string) *Client {
+	return &Client{
+		APPID:      appID,
+		APPKey:     appKey,
+		BaseURL:    BaseURL,
+		HTTPClient: http.DefaultClient,
+		cstLoc:     time.FixedZone("CST", 8*3600),
+	}
+}
+
+// commonResponse is the envelope every GNAME API response embeds.
+type commonResponse struct {
+	Code int    `json:"code,omitempty"`
+	Msg  string `json:"msg,omitempty"`
+}
+
+// do signs and sends a GNAME API request, decoding the response into
+// responseType. params must already be the endpoint's form-encoded
+// arguments, including appid but excluding gntime/gntoken, which do is
+// responsible for.
+// The request is bound to ctx, so a canceled or expired ctx aborts it even
+// while the HTTP round trip is in flight.
+func do[T any](ctx context.Context, c *Client, method, endpoint, params string, responseType T) (T, error) {
+	gnTime := time.Now().In(c.cstLoc).Unix()
+
+	newEndpoint := fmt.Sprintf("%s?%s&gntime=%d", endpoint, params, gnTime)
+
+	sortedParams, err := gojoin.Join(c.BaseURL+newEndpoint, gojoin.Options{
+		Sep:       "&",
+		KVSep:     "=",
+		Order:     gojoin.ASCII,
+		URLCoding: gojoin.Encoding,
+	})
+	if err != nil {
+		return responseType, fmt.Errorf("failed to join parameters: %w", err)
+	}
+
+	signParams := sortedParams + c.APPKey
+
+	token := md5.Sum([]byte(signParams))
+	gnToken := strings.ToUpper(fmt.Sprintf("%x", token))
+
+	bodyStr := fmt.Sprintf("%s&gntoken=%s", sortedParams, gnToken)
+
+	fullURL := c.BaseURL + endpoint
+
+	u, err := url.Parse(fullURL)
+	if err != nil {
+		return responseType, fmt.Errorf("failed to parse URL: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), strings.NewReader(bodyStr))
+	if err != nil {
+		return responseType, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return responseType, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		if err := Body.Close(); err != nil {
+			log.Printf("Warning: failed to close response body: %v", err)
+		}
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return responseType, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	result, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return responseType, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var common commonResponse
+	if err := json.Unmarshal(result, &common); err != nil {
+		return responseType, fmt.Errorf("failed to parse common response: %w", err)
+	}
+
+	if common.Code != 1 {
+		return responseType, &APIError{Code: common.Code, Msg: common.Msg}
+	}
+
+	response := responseType
+	if err := json.Unmarshal(result, &response); err != nil {
+		return responseType, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,381 @@
+package client
+
+import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	testAppID  = "test-app-id"
+	testAppKey = "test-app-key"
+)
+
+// fakeGNAME is an in-memory stand-in for the GNAME resolution API, good
+// enough to drive the signing, marshaling and pagination logic in Client
+// without hitting the real service.
+type fakeGNAME struct {
+	t *testing.T
+
+	mu      sync.Mutex
+	nextID  int
+	records map[int]fakeRecord
+}
+
+type fakeRecord struct {
+	zone string
+	typ  string
+	sub  string
+	data string
+	ttl  string
+}
+
+func newFakeGNAME(t *testing.T) (*httptest.Server, *Client) {
+	t.Helper()
+
+	f := &fakeGNAME{t: t, nextID: 1, records: map[int]fakeRecord{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/resolution/list", f.handleList)
+	mux.HandleFunc("/api/resolution/add", f.handleAdd)
+	mux.HandleFunc("/api/resolution/edit", f.handleEdit)
+	mux.HandleFunc("/api/resolution/delete", f.handleDelete)
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	c := NewClient(testAppID, testAppKey)
+	c.BaseURL = server.URL
+
+	return server, c
+}
+
+// verifySignature re-derives gntoken the way the real API would: ASCII-sort
+// every form field except gntoken, percent-encode the values, join with
+// "&", append the app key, and MD5 it.
+func (f *fakeGNAME) verifySignature(r *http.Request) bool {
+	f.t.Helper()
+
+	if err := r.ParseForm(); err != nil {
+		f.t.Fatalf("failed to parse form: %v", err)
+	}
+
+	gotToken := r.FormValue("gntoken")
+	if gotToken == "" {
+		return false
+	}
+	if r.FormValue("gntime") == "" {
+		return false
+	}
+
+	keys := make([]string, 0, len(r.Form))
+	for key := range r.Form {
+		if key == "gntoken" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+url.QueryEscape(r.FormValue(key)))
+	}
+
+	signParams := strings.Join(pairs, "&") + testAppKey
+	sum := md5.Sum([]byte(signParams))
+	wantToken := strings.ToUpper(fmt.Sprintf("%x", sum))
+
+	return gotToken == wantToken
+}
+
+func (f *fakeGNAME) writeError(w http.ResponseWriter, code int, msg string) {
+	fmt.Fprintf(w, `{"code":%d,"msg":%q}`, code, msg)
+}
+
+func (f *fakeGNAME) handleList(w http.ResponseWriter, r *http.Request) {
+	if !f.verifySignature(r) {
+		f.writeError(w, 0, "bad signature")
+		return
+	}
+
+	zone := r.FormValue("ym")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if zone != "example.com" {
+		f.writeError(w, -1, "domain not found")
+		return
+	}
+
+	type matched struct {
+		id int
+		fakeRecord
+	}
+	var all []matched
+	for id, rec := range f.records {
+		if rec.zone == zone {
+			all = append(all, matched{id: id, fakeRecord: rec})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].id < all[j].id })
+
+	page, _ := strconv.Atoi(r.FormValue("page"))
+	pageSize, _ := strconv.Atoi(r.FormValue("pagesize"))
+	if page <= 0 {
+		page = 1
+	}
+
+	var pageRecords []matched
+	if pageSize > 0 {
+		start := (page - 1) * pageSize
+		if start > len(all) {
+			start = len(all)
+		}
+		end := start + pageSize
+		if end > len(all) {
+			end = len(all)
+		}
+		pageRecords = all[start:end]
+	} else {
+		pageRecords = all
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `{"code":1,"msg":"success","count":%d,"page":%d,"pagesize":%d,"data":[`, len(all), page, pageSize)
+	for i, m := range pageRecords {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `{"id":"%d","ym":%q,"zjt":%q,"lx":%q,"jxz":%q,"ttl":%q}`, m.id, m.zone, m.sub, m.typ, m.data, m.ttl)
+	}
+	sb.WriteString(`]}`)
+	w.Write([]byte(sb.String()))
+}
+
+func (f *fakeGNAME) handleAdd(w http.ResponseWriter, r *http.Request) {
+	if !f.verifySignature(r) {
+		f.writeError(w, 0, "bad signature")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := f.nextID
+	f.nextID++
+	f.records[id] = fakeRecord{
+		zone: r.FormValue("ym"),
+		typ:  r.FormValue("lx"),
+		sub:  r.FormValue("zj"),
+		data: r.FormValue("jlz"),
+		ttl:  r.FormValue("ttl"),
+	}
+
+	fmt.Fprintf(w, `{"code":1,"msg":"success","data":%d}`, id)
+}
+
+func (f *fakeGNAME) handleEdit(w http.ResponseWriter, r *http.Request) {
+	if !f.verifySignature(r) {
+		f.writeError(w, 0, "bad signature")
+		return
+	}
+
+	id, err := strconv.Atoi(r.FormValue("jxid"))
+	if err != nil {
+		f.writeError(w, -2, "invalid jxid")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.records[id]; !ok {
+		f.writeError(w, -3, "record not found")
+		return
+	}
+
+	f.records[id] = fakeRecord{
+		zone: r.FormValue("ym"),
+		typ:  r.FormValue("lx"),
+		sub:  r.FormValue("zj"),
+		data: r.FormValue("jlz"),
+		ttl:  r.FormValue("ttl"),
+	}
+
+	fmt.Fprintf(w, `{"code":1,"msg":"success","data":"%d"}`, id)
+}
+
+func (f *fakeGNAME) handleDelete(w http.ResponseWriter, r *http.Request) {
+	if !f.verifySignature(r) {
+		f.writeError(w, 0, "bad signature")
+		return
+	}
+
+	id, err := strconv.Atoi(r.FormValue("jxid"))
+	if err != nil {
+		f.writeError(w, -2, "invalid jxid")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.records[id]; !ok {
+		f.writeError(w, -3, "record not found")
+		return
+	}
+	delete(f.records, id)
+
+	fmt.Fprintf(w, `{"code":1,"msg":"success"}`)
+}
+
+func TestClient_AddRecord_signsRequest(t *testing.T) {
+	_, c := newFakeGNAME(t)
+
+	resp, err := c.AddRecord(context.Background(), "example.com", "TXT", "_acme-challenge", "token-value", "", 120)
+	if err != nil {
+		t.Fatalf("AddRecord() error = %v", err)
+	}
+	if resp.Data == 0 {
+		t.Fatalf("AddRecord() returned zero ID")
+	}
+}
+
+func TestClient_AddRecord_badAppKey(t *testing.T) {
+	_, c := newFakeGNAME(t)
+	c.APPKey = "wrong-key"
+
+	_, err := c.AddRecord(context.Background(), "example.com", "TXT", "_acme-challenge", "token-value", "", 120)
+	if err == nil {
+		t.Fatalf("AddRecord() expected signature error, got nil")
+	}
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("AddRecord() error type = %T, want *APIError", err)
+	}
+	if apiErr.Code == 1 {
+		t.Fatalf("AddRecord() unexpectedly succeeded with a bad app key")
+	}
+}
+
+func TestClient_ListRecords_pagination(t *testing.T) {
+	_, c := newFakeGNAME(t)
+
+	for i := 0; i < 5; i++ {
+		if _, err := c.AddRecord(context.Background(), "example.com", "TXT", fmt.Sprintf("rec%d", i), "v", "", 300); err != nil {
+			t.Fatalf("seeding AddRecord() error = %v", err)
+		}
+	}
+
+	page1, err := c.ListRecords(context.Background(), "example.com", 1, 2)
+	if err != nil {
+		t.Fatalf("ListRecords() page 1 error = %v", err)
+	}
+	if len(page1.Data) != 2 {
+		t.Fatalf("ListRecords() page 1 len = %d, want 2", len(page1.Data))
+	}
+	if page1.Count != 5 {
+		t.Fatalf("ListRecords() Count = %d, want 5", page1.Count)
+	}
+
+	page3, err := c.ListRecords(context.Background(), "example.com", 3, 2)
+	if err != nil {
+		t.Fatalf("ListRecords() page 3 error = %v", err)
+	}
+	if len(page3.Data) != 1 {
+		t.Fatalf("ListRecords() page 3 len = %d, want 1", len(page3.Data))
+	}
+}
+
+func TestClient_ListAllRecords_followsPagination(t *testing.T) {
+	_, c := newFakeGNAME(t)
+
+	// More records than one page holds, so ListAllRecords has to fetch
+	// three pages to collect them all.
+	const n = 2*listAllRecordsPageSize + 1
+	for i := 0; i < n; i++ {
+		if _, err := c.AddRecord(context.Background(), "example.com", "TXT", fmt.Sprintf("rec%d", i), "v", "", 300); err != nil {
+			t.Fatalf("seeding AddRecord() error = %v", err)
+		}
+	}
+
+	all, err := c.ListAllRecords(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("ListAllRecords() error = %v", err)
+	}
+	if len(all) != n {
+		t.Fatalf("ListAllRecords() len = %d, want %d", len(all), n)
+	}
+}
+
+func TestClient_EditRecord_propagatesID(t *testing.T) {
+	_, c := newFakeGNAME(t)
+
+	added, err := c.AddRecord(context.Background(), "example.com", "A", "www", "1.1.1.1", "", 300)
+	if err != nil {
+		t.Fatalf("AddRecord() error = %v", err)
+	}
+	recordID := strconv.Itoa(added.Data)
+
+	_, err = c.EditRecord(context.Background(), "example.com", "A", "www", "2.2.2.2", "", 300, recordID)
+	if err != nil {
+		t.Fatalf("EditRecord() error = %v", err)
+	}
+
+	list, err := c.ListRecords(context.Background(), "example.com", 0, 0)
+	if err != nil {
+		t.Fatalf("ListRecords() error = %v", err)
+	}
+	var found bool
+	for _, rec := range list.Data {
+		if rec.ID == recordID {
+			found = true
+			if rec.Jxz != "2.2.2.2" {
+				t.Fatalf("EditRecord() Jxz = %q, want %q", rec.Jxz, "2.2.2.2")
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("EditRecord() record %s not found after edit", recordID)
+	}
+}
+
+func TestClient_DeleteRecord(t *testing.T) {
+	_, c := newFakeGNAME(t)
+
+	added, err := c.AddRecord(context.Background(), "example.com", "TXT", "_acme-challenge", "token", "", 120)
+	if err != nil {
+		t.Fatalf("AddRecord() error = %v", err)
+	}
+	recordID := strconv.Itoa(added.Data)
+
+	if _, err := c.DeleteRecord(context.Background(), "example.com", recordID); err != nil {
+		t.Fatalf("DeleteRecord() error = %v", err)
+	}
+
+	if _, err := c.DeleteRecord(context.Background(), "example.com", recordID); err == nil {
+		t.Fatalf("DeleteRecord() expected error deleting already-deleted record")
+	}
+}
+
+func TestClient_ListRecords_unknownZone(t *testing.T) {
+	_, c := newFakeGNAME(t)
+
+	_, err := c.ListRecords(context.Background(), "not-registered.com", 0, 0)
+	if err == nil {
+		t.Fatalf("ListRecords() expected error for unregistered zone")
+	}
+	if _, ok := err.(*APIError); !ok {
+		t.Fatalf("ListRecords() error type = %T, want *APIError", err)
+	}
+}
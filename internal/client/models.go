@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+// ResolutionList is the response body of /api/resolution/list.
+type ResolutionList struct {
+	Code     int                      `json:"code,omitempty"`
+	Msg      string                   `json:"msg,omitempty"`
+	Data     []DomainResolutionRecord `json:"data,omitempty"`
+	Count    int                      `json:"count,omitempty"`
+	Page     int                      `json:"page,omitempty"`
+	PageSize int                      `json:"pagesize,omitempty"`
+}
+
+// DomainResolutionRecord is a single record as returned by
+// /api/resolution/list.
+type DomainResolutionRecord struct {
+	ID   string `json:"id,omitempty"`
+	Ym   string `json:"ym,omitempty"`
+	Zjt  string `json:"zjt,omitempty"`
+	Lx   string `json:"lx,omitempty"`
+	Jxz  string `json:"jxz,omitempty"`
+	Mx   string `json:"mx,omitempty"`
+	Xlid int    `json:"xlid,omitempty"`
+	Zt   string `json:"zt,omitempty"`
+	TTL  string `json:"ttl,omitempty"`
+}
+
+// AddDomainRecord is the response body of /api/resolution/add.
+type AddDomainRecord struct {
+	Code int    `json:"code,omitempty"`
+	Msg  string `json:"msg,omitempty"`
+	Data int    `json:"data,omitempty"`
+}
+
+// UpdateDomainRecord is the response body of /api/resolution/edit.
+type UpdateDomainRecord struct {
+	Code int    `json:"code,omitempty"`
+	Msg  string `json:"msg,omitempty"`
+	Data string `json:"data,omitempty"`
+}
+
+// DeleteDomainRecord is the response body of /api/resolution/delete.
+type DeleteDomainRecord struct {
+	Code int    `json:"code,omitempty"`
+	Msg  string `json:"msg,omitempty"`
+}
+
+// ListRecords lists the records for zone, paginated via page/pageSize.
+func (c *Client) ListRecords(ctx context.Context, zone string, page, pageSize int) (ResolutionList, error) {
+	params := fmt.Sprintf("appid=%s&ym=%s", c.APPID, zone)
+	if page > 0 {
+		params += fmt.Sprintf("&page=%d", page)
+	}
+	if pageSize > 0 {
+		params += fmt.Sprintf("&pagesize=%d", pageSize)
+	}
+
+	return do(ctx, c, "POST", "/api/resolution/list", params, ResolutionList{})
+}
+
+// listAllRecordsPageSize is the page size ListAllRecords requests; GNAME
+// caps pagesize well above what any zone is likely to need, so one page
+// covers almost every call and larger zones still terminate in a handful
+// of round trips.
+const listAllRecordsPageSize = 100
+
+// ListAllRecords lists every record for zone, following pagination until
+// ResolutionList.Count records have been collected.
+func (c *Client) ListAllRecords(ctx context.Context, zone string) ([]DomainResolutionRecord, error) {
+	var collected []DomainResolutionRecord
+	for page := 1; ; page++ {
+		resp, err := c.ListRecords(ctx, zone, page, listAllRecordsPageSize)
+		if err != nil {
+			return nil, err
+		}
+		collected = append(collected, resp.Data...)
+		if len(resp.Data) == 0 || len(collected) >= resp.Count {
+			return collected, nil
+		}
+	}
+}
+
+// AddRecord creates a record in zone and returns the response, whose Data
+// field carries the newly assigned GNAME record ID ("jxid"). mx is the MX
+// priority and should be left empty for every other record type.
+func (c *Client) AddRecord(ctx context.Context, zone, recordType, subDomain, data, mx string, ttlSeconds int) (AddDomainRecord, error) {
+	params := fmt.Sprintf("appid=%s&ym=%s&lx=%s&zj=%s&jlz=%s&ttl=%d",
+		c.APPID, zone, recordType, subDomain, data, ttlSeconds)
+	if mx != "" {
+		params += fmt.Sprintf("&mx=%s", mx)
+	}
+
+	return do(ctx, c, "POST", "/api/resolution/add", params, AddDomainRecord{})
+}
+
+// EditRecord updates the record identified by recordID in zone. mx is the MX
+// priority and should be left empty for every other record type.
+func (c *Client) EditRecord(ctx context.Context, zone, recordType, subDomain, data, mx string, ttlSeconds int, recordID string) (UpdateDomainRecord, error) {
+	params := fmt.Sprintf("appid=%s&ym=%s&lx=%s&zj=%s&jlz=%s&ttl=%d&jxid=%s",
+		c.APPID, zone, recordType, subDomain, data, ttlSeconds, recordID)
+	if mx != "" {
+		params += fmt.Sprintf("&mx=%s", mx)
+	}
+
+	return do(ctx, c, "POST", "/api/resolution/edit", params, UpdateDomainRecord{})
+}
+
+// DeleteRecord deletes the record identified by recordID in zone.
+func (c *Client) DeleteRecord(ctx context.Context, zone, recordID string) (DeleteDomainRecord, error) {
+	params := fmt.Sprintf("appid=%s&ym=%s&jxid=%s", c.APPID, zone, recordID)
+
+	return do(ctx, c, "POST", "/api/resolution/delete", params, DeleteDomainRecord{})
+}
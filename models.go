@@ -1,56 +1,19 @@
 package gname
 
 import (
+	"fmt"
 	"strconv"
 	"time"
 
+	internalclient "github.com/libdns/gname/internal/client"
 	"github.com/libdns/libdns"
 )
 
-type CommonResponse struct {
-	Code int    `json:"code,omitempty"`
-	Msg  string `json:"msg,omitempty"`
-}
-
-type ResolutionList struct {
-	Code     int                      `json:"code,omitempty"`
-	Msg      string                   `json:"msg,omitempty"`
-	Data     []DomainResolutionRecord `json:"data,omitempty"`
-	Count    int                      `json:"count,omitempty"`
-	Page     int                      `json:"page,omitempty"`
-	PageSize int                      `json:"pagesize,omitempty"`
-}
-
-type DomainResolutionRecord struct {
-	ID   string `json:"id,omitempty"`
-	Ym   string `json:"ym,omitempty"`
-	Zjt  string `json:"zjt,omitempty"`
-	Lx   string `json:"lx,omitempty"`
-	Jxz  string `json:"jxz,omitempty"`
-	Mx   string `json:"mx,omitempty"`
-	Xlid int    `json:"xlid,omitempty"`
-	Zt   string `json:"zt,omitempty"`
-	TTL  string `json:"ttl,omitempty"`
-}
-
-type AddDomainRecord struct {
-	Code int    `json:"code,omitempty"`
-	Msg  string `json:"msg,omitempty"`
-	Data int    `json:"data,omitempty"`
-}
-
-type UpdateDomainRecord struct {
-	Code int    `json:"code,omitempty"`
-	Msg  string `json:"msg,omitempty"`
-	Data string `json:"data,omitempty"`
-}
-
-type DeleteDomainRecord struct {
-	Code int    `json:"code,omitempty"`
-	Msg  string `json:"msg,omitempty"`
-}
-
-func (record DomainResolutionRecord) toLibdnsRecord(zone string) libdns.RR {
+// toLibdnsRecord converts a GNAME record to a GnameRecord relative to
+// callerZone. registeredZone is the GNAME-registered domain the record was
+// fetched under (as resolved by Provider.findZone), which may be a parent
+// of callerZone when callerZone is itself a delegated sub-domain.
+func toLibdnsRecord(record internalclient.DomainResolutionRecord, registeredZone, callerZone string) GnameRecord {
 	// Parse TTL from API response, default to 600 seconds if not provided or invalid
 	ttl := time.Second * 600
 	if record.TTL != "" {
@@ -59,15 +22,66 @@ func (record DomainResolutionRecord) toLibdnsRecord(zone string) libdns.RR {
 		}
 	}
 
-	return libdns.RR{
-		Name: record.Zjt,
+	fqdn := libdns.AbsoluteName(record.Zjt, registeredZone)
+
+	rr := libdns.RR{
+		Name: libdns.RelativeName(fqdn, callerZone),
 		Type: record.Lx,
-		Data: record.Jxz,
+		Data: gnameRecordData(record),
 		TTL:  ttl,
 	}
+
+	// Parse into the structured libdns type (libdns.MX, libdns.SRV,
+	// libdns.CAA, etc.) when GNAME's data is well-formed for record.Lx;
+	// otherwise fall back to the generic RR so a single malformed record
+	// doesn't break the whole GetRecords call.
+	parsed, err := rr.Parse()
+	if err != nil {
+		parsed = rr
+	}
+
+	return GnameRecord{Record: parsed, ID: record.ID}
+}
+
+// gnameRecordData reconstructs the flat value libdns expects in RR.Data from
+// GNAME's representation. GNAME already stores SRV and CAA values in Jxz as
+// the same flat "priority weight port target" / `flags tag "value"` strings
+// libdns uses, but splits MX priority into the separate Mx field, so that
+// case needs recombining.
+func gnameRecordData(record internalclient.DomainResolutionRecord) string {
+	if record.Lx == "MX" && record.Mx != "" {
+		return fmt.Sprintf("%s %s", record.Mx, record.Jxz)
+	}
+	return record.Jxz
+}
+
+// GnameRecord wraps a libdns.Record with the GNAME-assigned record ID
+// ("jxid"). GetRecords, AppendRecords and SetRecords return this type
+// (rather than a bare libdns.RR) so that callers who want to target a
+// specific record later (e.g. via Provider.DeleteRecordByID) don't have to
+// re-list the zone to recover the ID, while still exposing the structured
+// record type (libdns.MX, libdns.SRV, libdns.CAA, ...) via a type assertion
+// on Record. DeleteRecords returns the deleted records as plain libdns.RR
+// values.
+type GnameRecord struct {
+	Record libdns.Record
+	ID     string
 }
 
-// RR implements the libdns.Record interface
-func (record DomainResolutionRecord) RR() libdns.RR {
-	return record.toLibdnsRecord("")
+// RR implements the libdns.Record interface.
+func (record GnameRecord) RR() libdns.RR {
+	return record.Record.RR()
+}
+
+// libdnsToGnameParams extracts the GNAME "jlz" (record value) and "mx" (MX
+// priority) parameters for record. For most record types libdns.RR.Data is
+// already the flat value GNAME expects (this holds for SRV and CAA too,
+// since their RR() encodings match GNAME's Jxz format); MX is the one type
+// GNAME splits into a separate field, so it's special-cased here to mirror
+// gnameRecordData's inverse.
+func libdnsToGnameParams(record libdns.Record) (jlz, mx string) {
+	if mxRec, ok := record.(libdns.MX); ok {
+		return mxRec.Target, strconv.Itoa(int(mxRec.Preference))
+	}
+	return record.RR().Data, ""
 }
@@ -2,14 +2,24 @@ package gname
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
+	internalclient "github.com/libdns/gname/internal/client"
 	"github.com/libdns/libdns"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
 )
 
+// MaxConcurrent is a convenience value for Provider.Concurrency: assign it
+// to opt into a small worker pool for bulk record operations (e.g. zones
+// with many _acme-challenge records) instead of the serial default.
+const MaxConcurrent = 5
+
 var (
 	// Ensure Provider implements the libdns interfaces
 	_ libdns.RecordGetter   = (*Provider)(nil)
@@ -33,16 +43,48 @@ type Provider struct {
 	// If not specified, a sensible default will be used with appropriate timeouts.
 	HTTPClient *http.Client `json:"-"`
 
-	// mutex for protecting the initialization of the HTTP client
+	// Concurrency bounds how many per-record API calls AppendRecords,
+	// SetRecords, and DeleteRecords will have in flight at once. Zero (the
+	// default) preserves the original serial behavior; set it to
+	// MaxConcurrent or another value to parallelize bulk operations.
+	Concurrency int `json:"concurrency,omitempty"`
+
+	// CacheTTL, if positive, lets GetRecords reuse a previously fetched
+	// record list for up to this long instead of hitting
+	// /api/resolution/list again. It's off (zero) by default; ACME flows
+	// that call SetRecords then DeleteRecords for the same zone within
+	// seconds are the main beneficiary. The cache is invalidated
+	// automatically on any add/edit/delete this Provider makes, and
+	// manually via InvalidateCache for out-of-band changes.
+	CacheTTL time.Duration `json:"cache_ttl,omitempty"`
+
+	// mu protects the lazy initialization of client, plus cache.
 	mu sync.RWMutex
+
+	// client is the internal GNAME API client, initialized on first use.
+	client *internalclient.Client
+
+	// cache holds the last fetched record list per registered zone, used
+	// when CacheTTL is positive.
+	cache map[string]zoneCache
+
+	// sf coalesces concurrent record-list fetches for the same zone into a
+	// single API request.
+	sf singleflight.Group
+}
+
+// zoneCache is one entry in Provider.cache.
+type zoneCache struct {
+	records   []internalclient.DomainResolutionRecord
+	fetchedAt time.Time
 }
 
-// getHTTPClient returns the HTTP client, initializing it if necessary.
-func (p *Provider) getHTTPClient() *http.Client {
+// getClient returns the internal API client, initializing it if necessary.
+func (p *Provider) getClient() *internalclient.Client {
 	p.mu.RLock()
-	if p.HTTPClient != nil {
+	if p.client != nil {
 		defer p.mu.RUnlock()
-		return p.HTTPClient
+		return p.client
 	}
 	p.mu.RUnlock()
 
@@ -50,167 +92,370 @@ func (p *Provider) getHTTPClient() *http.Client {
 	defer p.mu.Unlock()
 
 	// Double-check after acquiring write lock
-	if p.HTTPClient == nil {
-		p.HTTPClient = &http.Client{
-			Timeout: 30 * time.Second,
+	if p.client == nil {
+		c := internalclient.NewClient(p.APPID, p.APPKey)
+		if p.HTTPClient != nil {
+			c.HTTPClient = p.HTTPClient
+		} else {
+			c.HTTPClient = &http.Client{Timeout: 30 * time.Second}
 		}
+		p.client = c
 	}
-	return p.HTTPClient
+	return p.client
+}
+
+// findZone resolves fqdn to the domain GNAME actually has registered on the
+// account, by trying fqdn itself and then progressively shorter parent
+// labels against /api/resolution/list until one is recognized. This mirrors
+// the zone-detection pattern used by other DNS-01 providers (e.g. easydns),
+// and is necessary because callers (ACME clients in particular) often pass
+// a zone such as "_acme-challenge.sub.example.com" that isn't itself the
+// registered domain, only a name within it.
+func (p *Provider) findZone(ctx context.Context, fqdn string) (string, error) {
+	fqdn = strings.TrimSuffix(fqdn, ".")
+	labels := strings.Split(fqdn, ".")
+
+	client := p.getClient()
+	for i := 0; i < len(labels)-1; i++ {
+		candidate := strings.Join(labels[i:], ".")
+
+		_, err := client.ListRecords(ctx, candidate, 0, 1)
+		if err == nil {
+			return candidate, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+	}
+
+	return "", fmt.Errorf("no registered GNAME domain matches %s", fqdn)
+}
+
+// concurrency returns the number of in-flight per-record API calls to
+// allow, defaulting to 1 (serial) when Concurrency is unset.
+func (p *Provider) concurrency() int {
+	if p.Concurrency <= 0 {
+		return 1
+	}
+	return p.Concurrency
+}
+
+// gnameSubDomain computes the "zj" parameter GNAME expects for a record
+// named recordName relative to zone, once zone has been resolved to
+// registeredZone. When zone is itself the registered domain this is just
+// recordName; when zone is a delegated sub-domain (see findZone) the
+// sub-domain portion is folded back in.
+func gnameSubDomain(recordName, zone, registeredZone string) string {
+	fqdn := libdns.AbsoluteName(recordName, zone)
+	return libdns.RelativeName(fqdn, registeredZone)
 }
 
 // GetRecords lists all the records in the zone.
 func (p *Provider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
-	trimmedZone := libdnsZoneToDnslaDomain(zone)
+	registeredZone, err := p.findZone(ctx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get records for zone %s: %w", zone, err)
+	}
 
-	params := fmt.Sprintf("appid=%s&ym=%s", p.APPID, trimmedZone)
+	return p.getRecords(ctx, zone, registeredZone)
+}
 
-	response, err := MakeApiRequestWithClient(p.getHTTPClient(), "POST", "/api/resolution/list", params, p.APPKey, ResolutionList{})
+// getRecords lists the records for zone once its registeredZone has already
+// been resolved via findZone, so callers that need both the zone and its
+// records (SetRecords, DeleteRecords) don't pay for resolving it twice.
+func (p *Provider) getRecords(ctx context.Context, zone, registeredZone string) ([]libdns.Record, error) {
+	records, err := p.listRecordsCached(ctx, registeredZone)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get records for zone %s: %w", zone, err)
 	}
 
-	recs := make([]libdns.Record, 0, len(response.Data))
-	for _, rec := range response.Data {
-		rr := rec.toLibdnsRecord(trimmedZone)
-		recs = append(recs, rr)
+	recs := make([]libdns.Record, 0, len(records))
+	for _, rec := range records {
+		recs = append(recs, toLibdnsRecord(rec, registeredZone, zone))
 	}
 	return recs, nil
 }
 
-// AppendRecords adds records to the zone. It returns the records that were added.
-func (p *Provider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
-	var successfullyAppendedRecords []libdns.Record
-	trimmedZone := libdnsZoneToDnslaDomain(zone)
-
-	for _, record := range records {
-		// Convert record to RR to access its fields
-		rr := record.RR()
-
-		params := fmt.Sprintf("appid=%s&ym=%s&lx=%s&zj=%s&jlz=%s&ttl=%.0f",
-			p.APPID, trimmedZone, rr.Type, rr.Name, rr.Data, rr.TTL.Seconds())
+// listRecordsCached returns the record list for registeredZone, from
+// Provider.cache if CacheTTL is positive and the cached entry hasn't
+// expired, otherwise from GNAME. Concurrent calls for the same zone are
+// coalesced via singleflight so only one of them hits the API.
+func (p *Provider) listRecordsCached(ctx context.Context, registeredZone string) ([]internalclient.DomainResolutionRecord, error) {
+	if p.CacheTTL > 0 {
+		p.mu.RLock()
+		entry, ok := p.cache[registeredZone]
+		p.mu.RUnlock()
+		if ok && time.Since(entry.fetchedAt) < p.CacheTTL {
+			return entry.records, nil
+		}
+	}
 
-		_, err := MakeApiRequestWithClient(p.getHTTPClient(), "POST", "/api/resolution/add", params, p.APPKey, AddDomainRecord{})
+	v, err, _ := p.sf.Do(registeredZone, func() (interface{}, error) {
+		records, err := p.getClient().ListAllRecords(ctx, registeredZone)
 		if err != nil {
-			return successfullyAppendedRecords, fmt.Errorf("failed to append record %s.%s: %w", rr.Name, zone, err)
+			return nil, err
 		}
 
-		appendedRecord := libdns.RR{
-			Name: rr.Name,
-			Type: rr.Type,
-			Data: rr.Data,
-			TTL:  rr.TTL,
+		if p.CacheTTL > 0 {
+			p.mu.Lock()
+			if p.cache == nil {
+				p.cache = make(map[string]zoneCache)
+			}
+			p.cache[registeredZone] = zoneCache{records: records, fetchedAt: time.Now()}
+			p.mu.Unlock()
 		}
 
-		successfullyAppendedRecords = append(successfullyAppendedRecords, appendedRecord)
+		return records, nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return v.([]internalclient.DomainResolutionRecord), nil
+}
 
-	return successfullyAppendedRecords, nil
+// InvalidateCache discards any cached record list for zone, so the next
+// GetRecords call (including the ones SetRecords/DeleteRecords make
+// internally) fetches fresh data. Call this after mutating records through
+// some channel other than this Provider, e.g. the GNAME web console.
+func (p *Provider) InvalidateCache(zone string) {
+	registeredZone, err := p.findZone(context.Background(), zone)
+	if err != nil {
+		// Nothing could have been cached under a zone we can't resolve.
+		return
+	}
+	p.invalidateCache(registeredZone)
 }
 
-// SetRecords sets the records in the zone, either by updating existing records or creating new ones.
-// It returns the updated records.
-func (p *Provider) SetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
-	var successfullyUpdatedRecords []libdns.Record
-	trimmedZone := libdnsZoneToDnslaDomain(zone)
+// invalidateCache drops the cache entry for an already-resolved registered
+// zone.
+func (p *Provider) invalidateCache(registeredZone string) {
+	p.mu.Lock()
+	delete(p.cache, registeredZone)
+	p.mu.Unlock()
+}
 
-	recs, err := p.GetRecords(ctx, zone)
+// AppendRecords adds records to the zone. It returns the records that were
+// added.
+//
+// Records are dispatched through a worker pool bounded by Provider.Concurrency.
+// If any record fails, the remaining in-flight work is canceled, but records
+// that already succeeded are still returned alongside a joined error
+// describing every failure.
+func (p *Provider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	registeredZone, err := p.findZone(ctx, zone)
 	if err != nil {
-		return successfullyUpdatedRecords, fmt.Errorf("failed to get existing records: %w", err)
+		return nil, fmt.Errorf("failed to resolve zone for %s: %w", zone, err)
 	}
 
-	for _, record := range records {
-		rr := record.RR()
-		hasRecord := false
-		recordId := ""
+	results := make([]libdns.Record, len(records))
+	errs := make([]error, len(records))
 
-		for _, rec := range recs {
-			recRR := rec.RR()
-			if recRR.Name == rr.Name && recRR.Type == rr.Type {
-				hasRecord = true
-				// Try to extract ID from the record if it's our custom type
-				if dnslaRec, ok := rec.(DomainResolutionRecord); ok {
-					recordId = dnslaRec.ID
-				}
-				break
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(p.concurrency())
+
+	for i, record := range records {
+		i, record := i, record
+		eg.Go(func() error {
+			if err := egCtx.Err(); err != nil {
+				errs[i] = err
+				return err
 			}
-		}
 
-		if !hasRecord {
-			appendedRecords, err := p.AppendRecords(ctx, zone, []libdns.Record{record})
+			rr := record.RR()
+			subDomain := gnameSubDomain(rr.Name, zone, registeredZone)
+			jlz, mx := libdnsToGnameParams(record)
+
+			response, err := p.getClient().AddRecord(egCtx, registeredZone, rr.Type, subDomain, jlz, mx, int(rr.TTL.Seconds()))
 			if err != nil {
-				return successfullyUpdatedRecords, fmt.Errorf("failed to create new record: %w", err)
+				errs[i] = fmt.Errorf("failed to append record %s.%s: %w", rr.Name, zone, err)
+				return errs[i]
 			}
 
-			successfullyUpdatedRecords = append(successfullyUpdatedRecords, appendedRecords...)
-			continue
-		}
+			results[i] = GnameRecord{
+				Record: record,
+				ID:     fmt.Sprintf("%d", response.Data),
+			}
+			return nil
+		})
+	}
+	_ = eg.Wait()
+	p.invalidateCache(registeredZone)
+
+	return successfulRecords(results, errs), errors.Join(errs...)
+}
 
-		if recordId == "" {
-			// Skip if we can't get the record ID
-			continue
+// successfulRecords returns the entries of results whose corresponding
+// errs slot is nil, preserving order.
+func successfulRecords(results []libdns.Record, errs []error) []libdns.Record {
+	successful := make([]libdns.Record, 0, len(results))
+	for i, rec := range results {
+		if errs[i] == nil && rec != nil {
+			successful = append(successful, rec)
 		}
+	}
+	return successful
+}
 
-		params := fmt.Sprintf("appid=%s&ym=%s&lx=%s&zj=%s&jlz=%s&ttl=%.0f&jxid=%s",
-			p.APPID, trimmedZone, rr.Type, rr.Name, rr.Data, rr.TTL.Seconds(), recordId)
+// SetRecords sets the records in the zone, either by updating existing
+// records or creating new ones. It returns the updated records.
+//
+// Like AppendRecords, records are dispatched through a worker pool bounded
+// by Provider.Concurrency, and a failure on one record doesn't prevent the
+// others from being reported as successful.
+func (p *Provider) SetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	registeredZone, err := p.findZone(ctx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve zone for %s: %w", zone, err)
+	}
 
-		response, err := MakeApiRequestWithClient(p.getHTTPClient(), "POST", "/api/resolution/edit", params, p.APPKey, UpdateDomainRecord{})
-		if err != nil {
-			return successfullyUpdatedRecords, fmt.Errorf("failed to update record %s.%s: %w", rr.Name, zone, err)
-		}
+	recs, err := p.getRecords(ctx, zone, registeredZone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing records: %w", err)
+	}
 
-		if response.Code == 1 {
-			successfullyUpdatedRecords = append(successfullyUpdatedRecords, libdns.RR{
-				Name: rr.Name,
-				Type: rr.Type,
-				Data: rr.Data,
-				TTL:  rr.TTL,
-			})
-		}
+	results := make([]libdns.Record, len(records))
+	errs := make([]error, len(records))
+
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(p.concurrency())
+
+	for i, record := range records {
+		i, record := i, record
+		eg.Go(func() error {
+			if err := egCtx.Err(); err != nil {
+				errs[i] = err
+				return err
+			}
+
+			rr := record.RR()
+			recordId := ""
+			for _, rec := range recs {
+				recRR := rec.RR()
+				if recRR.Name == rr.Name && recRR.Type == rr.Type {
+					if gnameRec, ok := rec.(GnameRecord); ok {
+						recordId = gnameRec.ID
+					}
+					break
+				}
+			}
+
+			subDomain := gnameSubDomain(rr.Name, zone, registeredZone)
+			jlz, mx := libdnsToGnameParams(record)
+
+			if recordId == "" {
+				response, err := p.getClient().AddRecord(egCtx, registeredZone, rr.Type, subDomain, jlz, mx, int(rr.TTL.Seconds()))
+				if err != nil {
+					errs[i] = fmt.Errorf("failed to create new record %s.%s: %w", rr.Name, zone, err)
+					return errs[i]
+				}
+				results[i] = GnameRecord{Record: record, ID: fmt.Sprintf("%d", response.Data)}
+				return nil
+			}
+
+			response, err := p.getClient().EditRecord(egCtx, registeredZone, rr.Type, subDomain, jlz, mx, int(rr.TTL.Seconds()), recordId)
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to update record %s.%s: %w", rr.Name, zone, err)
+				return errs[i]
+			}
+			if response.Code != 1 {
+				errs[i] = fmt.Errorf("gname rejected update of record %s.%s: %s", rr.Name, zone, response.Msg)
+				return errs[i]
+			}
+			results[i] = GnameRecord{Record: record, ID: recordId}
+			return nil
+		})
 	}
+	_ = eg.Wait()
+	p.invalidateCache(registeredZone)
 
-	return successfullyUpdatedRecords, nil
+	return successfulRecords(results, errs), errors.Join(errs...)
 }
 
-// DeleteRecords deletes the records from the zone. It returns the records that were deleted.
+// DeleteRecords deletes the records from the zone. It returns the records
+// that were deleted.
+//
+// Like AppendRecords, records are dispatched through a worker pool bounded
+// by Provider.Concurrency, and a failure on one record doesn't prevent the
+// others from being reported as successful.
 func (p *Provider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
-	var successfullyDeletedRecords []libdns.Record
-	trimmedZone := libdnsZoneToDnslaDomain(zone)
+	registeredZone, err := p.findZone(ctx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve zone for %s: %w", zone, err)
+	}
 
-	recs, err := p.GetRecords(ctx, zone)
+	recs, err := p.getRecords(ctx, zone, registeredZone)
 	if err != nil {
-		return successfullyDeletedRecords, fmt.Errorf("failed to get existing records: %w", err)
+		return nil, fmt.Errorf("failed to get existing records: %w", err)
 	}
 
-	for _, record := range records {
-		rr := record.RR()
-		recordId := ""
+	results := make([]libdns.Record, len(records))
+	errs := make([]error, len(records))
+
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(p.concurrency())
+
+	for i, record := range records {
+		i, record := i, record
+		eg.Go(func() error {
+			if err := egCtx.Err(); err != nil {
+				errs[i] = err
+				return err
+			}
 
-		for _, rec := range recs {
-			recRR := rec.RR()
-			if recRR.Name == rr.Name && recRR.Type == rr.Type {
-				// Try to extract ID from the record if it's our custom type
-				if dnslaRec, ok := rec.(DomainResolutionRecord); ok {
-					recordId = dnslaRec.ID
+			rr := record.RR()
+			recordId := ""
+			for _, rec := range recs {
+				recRR := rec.RR()
+				if recRR.Name == rr.Name && recRR.Type == rr.Type {
+					if gnameRec, ok := rec.(GnameRecord); ok {
+						recordId = gnameRec.ID
+					}
+					break
 				}
-				break
 			}
-		}
 
-		if recordId == "" {
-			// Skip if we can't find the record ID
-			continue
-		}
+			if recordId == "" {
+				// Nothing to delete; not an error, just nothing done.
+				return nil
+			}
+
+			response, err := p.getClient().DeleteRecord(egCtx, registeredZone, recordId)
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to delete record %s.%s: %w", rr.Name, zone, err)
+				return errs[i]
+			}
+			if response.Code == 1 {
+				results[i] = rr
+			}
+			return nil
+		})
+	}
+	_ = eg.Wait()
+	p.invalidateCache(registeredZone)
 
-		params := fmt.Sprintf("appid=%s&ym=%s&jxid=%s", p.APPID, trimmedZone, recordId)
+	return successfulRecords(results, errs), errors.Join(errs...)
+}
 
-		response, err := MakeApiRequestWithClient(p.getHTTPClient(), "POST", "/api/resolution/delete", params, p.APPKey, DeleteDomainRecord{})
-		if err != nil {
-			return successfullyDeletedRecords, fmt.Errorf("failed to delete record %s.%s: %w", rr.Name, zone, err)
-		}
-		if response.Code == 1 {
-			successfullyDeletedRecords = append(successfullyDeletedRecords, rr)
-		}
+// DeleteRecordByID deletes a single record identified by its GNAME record ID
+// ("jxid"), skipping the list-then-match lookup DeleteRecords performs. This
+// is the efficient path for high-frequency ACME renewals: AppendRecords
+// already returns the ID in GnameRecord, so a caller that stashes it can
+// clean up the challenge record directly.
+func (p *Provider) DeleteRecordByID(ctx context.Context, zone, id string) error {
+	registeredZone, err := p.findZone(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("failed to resolve zone for %s: %w", zone, err)
 	}
 
-	return successfullyDeletedRecords, nil
+	response, err := p.getClient().DeleteRecord(ctx, registeredZone, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete record %s in zone %s: %w", id, zone, err)
+	}
+	if response.Code != 1 {
+		return fmt.Errorf("gname rejected delete of record %s: %s (code %d)", id, response.Msg, response.Code)
+	}
+
+	p.invalidateCache(registeredZone)
+
+	return nil
 }
@@ -2,228 +2,601 @@ package gname
 
 import (
 	"context"
-	"reflect"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/libdns/libdns"
 )
 
-func TestProvider_AppendRecords(t *testing.T) {
-	type fields struct {
-		APPID  string
-		APPKey string
-	}
-	type args struct {
-		ctx     context.Context
-		zone    string
-		records []libdns.Record
-	}
-	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    []libdns.Record
-		wantErr bool
-	}{
-		{
-			name: "Test AppendRecords",
-			fields: fields{
-				APPID:  "Your_APPID",
-				APPKey: "Your_APPKEY",
-			},
-			args: args{
-				ctx:  context.Background(),
-				zone: "388vip.com",
-				records: []libdns.Record{
-					libdns.RR{
-						Name: "jump-test",
-						Type: "A",
-						Data: "8.8.8.8",
-						TTL:  time.Second * 120,
-					},
-				},
-			},
-			want:    nil,
-			wantErr: true,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			p := &Provider{
-				APPID:  tt.fields.APPID,
-				APPKey: tt.fields.APPKey,
-			}
-			got, err := p.AppendRecords(tt.args.ctx, tt.args.zone, tt.args.records)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("AppendRecords() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("AppendRecords() got = %v, want %v", got, tt.want)
+const (
+	testZone   = "example.com"
+	testAppID  = "test-app-id"
+	testAppKey = "test-app-key"
+)
+
+// fakeRecord is the fake server's in-memory representation of a GNAME
+// record.
+type fakeRecord struct {
+	typ  string
+	name string
+	data string
+	ttl  string
+	mx   string
+}
+
+// newFakeGNAME starts an httptest server speaking enough of the GNAME
+// /api/resolution/{list,add,edit,delete} protocol to drive Provider without
+// hitting the real API, and returns a Provider wired to it along with a
+// counter of how many times /api/resolution/list was hit (for cache tests).
+func newFakeGNAME(t *testing.T) (*Provider, *int64) {
+	t.Helper()
+
+	var mu sync.Mutex
+	nextID := 1
+	records := map[int]fakeRecord{}
+	var listCalls int64
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/resolution/list", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&listCalls, 1)
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("parse form: %v", err)
+		}
+		if r.FormValue("ym") != testZone {
+			fmt.Fprint(w, `{"code":-1,"msg":"domain not found"}`)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, `{"code":1,"msg":"success","count":%d,"data":[`, len(records))
+		first := true
+		for id, rec := range records {
+			if !first {
+				sb.WriteString(",")
 			}
-		})
+			first = false
+			fmt.Fprintf(&sb, `{"id":"%d","zjt":%q,"lx":%q,"jxz":%q,"ttl":%q,"mx":%q}`, id, rec.name, rec.typ, rec.data, rec.ttl, rec.mx)
+		}
+		sb.WriteString("]}")
+		fmt.Fprint(w, sb.String())
+	})
+
+	mux.HandleFunc("/api/resolution/add", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("parse form: %v", err)
+		}
+		if r.FormValue("jlz") == "force-fail" {
+			// Delay the failure response so concurrent sibling requests (which
+			// don't sleep) land first; otherwise this races with the
+			// failure-triggered cancellation of in-flight siblings.
+			time.Sleep(50 * time.Millisecond)
+			fmt.Fprint(w, `{"code":-4,"msg":"forced failure"}`)
+			return
+		}
+
+		mu.Lock()
+		id := nextID
+		nextID++
+		records[id] = fakeRecord{
+			typ:  r.FormValue("lx"),
+			name: r.FormValue("zj"),
+			data: r.FormValue("jlz"),
+			ttl:  r.FormValue("ttl"),
+			mx:   r.FormValue("mx"),
+		}
+		mu.Unlock()
+
+		fmt.Fprintf(w, `{"code":1,"msg":"success","data":%d}`, id)
+	})
+
+	mux.HandleFunc("/api/resolution/edit", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("parse form: %v", err)
+		}
+
+		var id int
+		fmt.Sscanf(r.FormValue("jxid"), "%d", &id)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := records[id]; !ok {
+			fmt.Fprint(w, `{"code":-3,"msg":"record not found"}`)
+			return
+		}
+		records[id] = fakeRecord{
+			typ:  r.FormValue("lx"),
+			name: r.FormValue("zj"),
+			data: r.FormValue("jlz"),
+			ttl:  r.FormValue("ttl"),
+			mx:   r.FormValue("mx"),
+		}
+		fmt.Fprintf(w, `{"code":1,"msg":"success","data":"%d"}`, id)
+	})
+
+	mux.HandleFunc("/api/resolution/delete", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("parse form: %v", err)
+		}
+
+		var id int
+		fmt.Sscanf(r.FormValue("jxid"), "%d", &id)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := records[id]; !ok {
+			fmt.Fprint(w, `{"code":-3,"msg":"record not found"}`)
+			return
+		}
+		delete(records, id)
+		fmt.Fprint(w, `{"code":1,"msg":"success"}`)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	p := &Provider{APPID: testAppID, APPKey: testAppKey}
+	p.getClient().BaseURL = server.URL
+
+	return p, &listCalls
+}
+
+// TestProvider_findZone_walksToParentLabel exercises findZone's actual
+// reason for existing: a caller passing a delegated name several labels
+// below the registered domain (e.g. an ACME client handed
+// "_acme-challenge.sub.example.com" as the "zone" to manage) should still
+// resolve against the real registered domain, with the intervening labels
+// folded into the record name GNAME is sent.
+func TestProvider_findZone_walksToParentLabel(t *testing.T) {
+	p, _ := newFakeGNAME(t)
+
+	const callerZone = "_acme-challenge.sub.example.com"
+
+	added, err := p.AppendRecords(context.Background(), callerZone, []libdns.Record{
+		libdns.RR{Name: "@", Type: "TXT", Data: "token-value", TTL: 120 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("AppendRecords() error = %v", err)
+	}
+	if len(added) != 1 {
+		t.Fatalf("AppendRecords() len = %d, want 1", len(added))
+	}
+
+	recs, err := p.GetRecords(context.Background(), callerZone)
+	if err != nil {
+		t.Fatalf("GetRecords() error = %v", err)
+	}
+	if len(recs) != 1 {
+		t.Fatalf("GetRecords() len = %d, want 1", len(recs))
+	}
+	if got := recs[0].RR().Name; got != "@" {
+		t.Fatalf("GetRecords() Name = %q, want %q (relative to the caller-supplied zone)", got, "@")
+	}
+}
+
+func TestProvider_GetRecords_TTLParsing(t *testing.T) {
+	p, _ := newFakeGNAME(t)
+
+	_, err := p.AppendRecords(context.Background(), testZone, []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "1.1.1.1", TTL: 300 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("AppendRecords() error = %v", err)
+	}
+
+	recs, err := p.GetRecords(context.Background(), testZone)
+	if err != nil {
+		t.Fatalf("GetRecords() error = %v", err)
+	}
+	if len(recs) != 1 {
+		t.Fatalf("GetRecords() len = %d, want 1", len(recs))
+	}
+	if got := recs[0].RR().TTL; got != 300*time.Second {
+		t.Errorf("GetRecords() TTL = %v, want %v", got, 300*time.Second)
+	}
+}
+
+func TestProvider_AppendRecords_mxRoundTrips(t *testing.T) {
+	p, _ := newFakeGNAME(t)
+
+	_, err := p.AppendRecords(context.Background(), testZone, []libdns.Record{
+		libdns.MX{Name: "@", TTL: 300 * time.Second, Preference: 10, Target: "mail.example.com."},
+	})
+	if err != nil {
+		t.Fatalf("AppendRecords() error = %v", err)
+	}
+
+	recs, err := p.GetRecords(context.Background(), testZone)
+	if err != nil {
+		t.Fatalf("GetRecords() error = %v", err)
+	}
+	if len(recs) != 1 {
+		t.Fatalf("GetRecords() len = %d, want 1", len(recs))
+	}
+	gnameRec, ok := recs[0].(GnameRecord)
+	if !ok {
+		t.Fatalf("GetRecords() returned %T, want GnameRecord", recs[0])
+	}
+	mx, ok := gnameRec.Record.(libdns.MX)
+	if !ok {
+		t.Fatalf("GnameRecord.Record = %T, want libdns.MX", gnameRec.Record)
+	}
+	if mx.Preference != 10 || mx.Target != "mail.example.com." {
+		t.Fatalf("GetRecords() MX = %+v, want Preference 10, Target mail.example.com.", mx)
+	}
+}
+
+func TestProvider_AppendRecords_srvRoundTrips(t *testing.T) {
+	p, _ := newFakeGNAME(t)
+
+	_, err := p.AppendRecords(context.Background(), testZone, []libdns.Record{
+		libdns.SRV{Service: "sip", Transport: "tcp", Name: "@", TTL: 60 * time.Second, Priority: 10, Weight: 20, Port: 5060, Target: "sipserver.example.com."},
+	})
+	if err != nil {
+		t.Fatalf("AppendRecords() error = %v", err)
+	}
+
+	recs, err := p.GetRecords(context.Background(), testZone)
+	if err != nil {
+		t.Fatalf("GetRecords() error = %v", err)
+	}
+	if len(recs) != 1 {
+		t.Fatalf("GetRecords() len = %d, want 1", len(recs))
+	}
+	gnameRec, ok := recs[0].(GnameRecord)
+	if !ok {
+		t.Fatalf("GetRecords() returned %T, want GnameRecord", recs[0])
+	}
+	srv, ok := gnameRec.Record.(libdns.SRV)
+	if !ok {
+		t.Fatalf("GnameRecord.Record = %T, want libdns.SRV", gnameRec.Record)
+	}
+	if srv.Priority != 10 || srv.Weight != 20 || srv.Port != 5060 || srv.Target != "sipserver.example.com." {
+		t.Fatalf("GetRecords() SRV = %+v, want Priority 10, Weight 20, Port 5060, Target sipserver.example.com.", srv)
+	}
+}
+
+func TestProvider_AppendRecords_caaRoundTrips(t *testing.T) {
+	p, _ := newFakeGNAME(t)
+
+	_, err := p.AppendRecords(context.Background(), testZone, []libdns.Record{
+		libdns.CAA{Name: "@", TTL: 60 * time.Second, Flags: 0, Tag: "issue", Value: "letsencrypt.org"},
+	})
+	if err != nil {
+		t.Fatalf("AppendRecords() error = %v", err)
+	}
+
+	recs, err := p.GetRecords(context.Background(), testZone)
+	if err != nil {
+		t.Fatalf("GetRecords() error = %v", err)
+	}
+	if len(recs) != 1 {
+		t.Fatalf("GetRecords() len = %d, want 1", len(recs))
+	}
+	gnameRec, ok := recs[0].(GnameRecord)
+	if !ok {
+		t.Fatalf("GetRecords() returned %T, want GnameRecord", recs[0])
+	}
+	caa, ok := gnameRec.Record.(libdns.CAA)
+	if !ok {
+		t.Fatalf("GnameRecord.Record = %T, want libdns.CAA", gnameRec.Record)
+	}
+	if caa.Flags != 0 || caa.Tag != "issue" || caa.Value != "letsencrypt.org" {
+		t.Fatalf("GetRecords() CAA = %+v, want Flags 0, Tag issue, Value letsencrypt.org", caa)
+	}
+}
+
+func TestProvider_SetRecords_dedupsByNameAndType(t *testing.T) {
+	p, _ := newFakeGNAME(t)
+
+	_, err := p.AppendRecords(context.Background(), testZone, []libdns.Record{
+		libdns.RR{Name: "_acme-challenge", Type: "TXT", Data: "old-token", TTL: 120 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("AppendRecords() error = %v", err)
+	}
+
+	updated, err := p.SetRecords(context.Background(), testZone, []libdns.Record{
+		libdns.RR{Name: "_acme-challenge", Type: "TXT", Data: "new-token", TTL: 120 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("SetRecords() error = %v", err)
+	}
+	if len(updated) != 1 {
+		t.Fatalf("SetRecords() len = %d, want 1", len(updated))
+	}
+	if updated[0].RR().Data != "new-token" {
+		t.Fatalf("SetRecords() Data = %q, want %q", updated[0].RR().Data, "new-token")
+	}
+
+	recs, err := p.GetRecords(context.Background(), testZone)
+	if err != nil {
+		t.Fatalf("GetRecords() error = %v", err)
+	}
+	if len(recs) != 1 {
+		t.Fatalf("GetRecords() after SetRecords() len = %d, want 1 (matching Name+Type should update, not duplicate)", len(recs))
+	}
+}
+
+// TestProvider_SetRecords_resolvesZoneOnce guards against SetRecords
+// re-resolving the zone via GetRecords after already having done so itself,
+// which would cost an extra uncached /api/resolution/list round trip on
+// every call.
+func TestProvider_SetRecords_resolvesZoneOnce(t *testing.T) {
+	p, listCalls := newFakeGNAME(t)
+
+	beforeCount := atomic.LoadInt64(listCalls)
+	_, err := p.SetRecords(context.Background(), testZone, []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "1.1.1.1", TTL: 60 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("SetRecords() error = %v", err)
+	}
+
+	// One call to resolve the zone via findZone, one to list its existing
+	// records: two total, not the three it costs when SetRecords resolves
+	// the zone itself and then calls GetRecords (which resolves it again).
+	if got := atomic.LoadInt64(listCalls) - beforeCount; got != 2 {
+		t.Fatalf("list calls for SetRecords() = %d, want 2 (one zone resolution, one record list)", got)
+	}
+}
+
+// TestProvider_DeleteRecords_resolvesZoneOnce is the DeleteRecords analog of
+// TestProvider_SetRecords_resolvesZoneOnce.
+func TestProvider_DeleteRecords_resolvesZoneOnce(t *testing.T) {
+	p, listCalls := newFakeGNAME(t)
+
+	added, err := p.AppendRecords(context.Background(), testZone, []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "1.1.1.1", TTL: 60 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("AppendRecords() error = %v", err)
+	}
+
+	beforeCount := atomic.LoadInt64(listCalls)
+	if _, err := p.DeleteRecords(context.Background(), testZone, added); err != nil {
+		t.Fatalf("DeleteRecords() error = %v", err)
+	}
+
+	if got := atomic.LoadInt64(listCalls) - beforeCount; got != 2 {
+		t.Fatalf("list calls for DeleteRecords() = %d, want 2 (one zone resolution, one record list)", got)
+	}
+}
+
+func TestProvider_SetRecords_jxidPropagation(t *testing.T) {
+	p, _ := newFakeGNAME(t)
+
+	appended, err := p.AppendRecords(context.Background(), testZone, []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "1.1.1.1", TTL: 300 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("AppendRecords() error = %v", err)
+	}
+	gnameRec, ok := appended[0].(GnameRecord)
+	if !ok {
+		t.Fatalf("AppendRecords() returned %T, want GnameRecord", appended[0])
+	}
+
+	if _, err := p.SetRecords(context.Background(), testZone, []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "2.2.2.2", TTL: 300 * time.Second},
+	}); err != nil {
+		t.Fatalf("SetRecords() error = %v", err)
+	}
+
+	if err := p.DeleteRecordByID(context.Background(), testZone, gnameRec.ID); err != nil {
+		t.Fatalf("DeleteRecordByID() error = %v", err)
+	}
+
+	recs, err := p.GetRecords(context.Background(), testZone)
+	if err != nil {
+		t.Fatalf("GetRecords() error = %v", err)
+	}
+	if len(recs) != 0 {
+		t.Fatalf("GetRecords() after delete len = %d, want 0", len(recs))
 	}
 }
 
 func TestProvider_DeleteRecords(t *testing.T) {
-	type fields struct {
-		APPID  string
-		APPKey string
-	}
-	type args struct {
-		ctx     context.Context
-		zone    string
-		records []libdns.Record
-	}
-	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    []libdns.Record
-		wantErr bool
-	}{
-		{
-			name: "Test DeleteRecords",
-			fields: fields{
-				APPID:  "Your_APPID",
-				APPKey: "Your_APPKEY",
-			},
-			args: args{
-				ctx:  context.Background(),
-				zone: "388vip.com",
-				records: []libdns.Record{
-					libdns.RR{
-						Name: "jump-test",
-						Type: "A",
-						Data: "8.8.8.8",
-						TTL:  time.Second * 120,
-					},
-				},
-			},
-			want:    nil,
-			wantErr: true,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			p := &Provider{
-				APPID:  tt.fields.APPID,
-				APPKey: tt.fields.APPKey,
-			}
-			got, err := p.DeleteRecords(tt.args.ctx, tt.args.zone, tt.args.records)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("DeleteRecords() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("DeleteRecords() got = %v, want %v", got, tt.want)
-			}
-		})
+	p, _ := newFakeGNAME(t)
+
+	_, err := p.AppendRecords(context.Background(), testZone, []libdns.Record{
+		libdns.RR{Name: "jump-test", Type: "A", Data: "8.8.8.8", TTL: 120 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("AppendRecords() error = %v", err)
+	}
+
+	deleted, err := p.DeleteRecords(context.Background(), testZone, []libdns.Record{
+		libdns.RR{Name: "jump-test", Type: "A", Data: "8.8.8.8", TTL: 120 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("DeleteRecords() error = %v", err)
+	}
+	if len(deleted) != 1 {
+		t.Fatalf("DeleteRecords() len = %d, want 1", len(deleted))
+	}
+
+	recs, err := p.GetRecords(context.Background(), testZone)
+	if err != nil {
+		t.Fatalf("GetRecords() error = %v", err)
+	}
+	if len(recs) != 0 {
+		t.Fatalf("GetRecords() after DeleteRecords() len = %d, want 0", len(recs))
 	}
 }
 
-func TestProvider_GetRecords(t *testing.T) {
-	type fields struct {
-		APPID  string
-		APPKey string
-	}
-	type args struct {
-		ctx  context.Context
-		zone string
-	}
-	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    []libdns.Record
-		wantErr bool
-	}{
-		{
-			name: "Test GetRecords",
-			fields: fields{
-				APPID:  "Your_APPID",
-				APPKey: "Your_APPKEY",
-			},
-			args: args{
-				ctx:  context.Background(),
-				zone: "388vip.com",
-			},
-			want:    nil,
-			wantErr: true,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			p := &Provider{
-				APPID:  tt.fields.APPID,
-				APPKey: tt.fields.APPKey,
-			}
-			got, err := p.GetRecords(tt.args.ctx, tt.args.zone)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("GetRecords() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("GetRecords() got = %v, want %v", got, tt.want)
-			}
-		})
+func TestProvider_AppendRecords_partialFailure(t *testing.T) {
+	p, _ := newFakeGNAME(t)
+	p.Concurrency = MaxConcurrent
+
+	got, err := p.AppendRecords(context.Background(), testZone, []libdns.Record{
+		libdns.RR{Name: "good1", Type: "TXT", Data: "ok", TTL: 60 * time.Second},
+		libdns.RR{Name: "bad", Type: "TXT", Data: "force-fail", TTL: 60 * time.Second},
+		libdns.RR{Name: "good2", Type: "TXT", Data: "ok", TTL: 60 * time.Second},
+	})
+
+	if err == nil {
+		t.Fatalf("AppendRecords() expected a joined error, got nil")
+	}
+	if len(got) != 2 {
+		t.Fatalf("AppendRecords() returned %d successful records, want 2 (failures shouldn't drop the rest)", len(got))
 	}
 }
 
-func TestProvider_SetRecords(t *testing.T) {
-	type fields struct {
-		APPID  string
-		APPKey string
-	}
-	type args struct {
-		ctx     context.Context
-		zone    string
-		records []libdns.Record
-	}
-	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    []libdns.Record
-		wantErr bool
-	}{
-		{
-			name: "Test SetRecords",
-			fields: fields{
-				APPID:  "Your_APPID",
-				APPKey: "Your_APPKEY",
-			},
-			args: args{
-				ctx:  context.Background(),
-				zone: "388vip.com",
-				records: []libdns.Record{
-					libdns.RR{
-						Name: "jump-test",
-						Type: "A",
-						Data: "8.8.8.8",
-						TTL:  time.Second * 120,
-					},
-				},
-			},
-			want:    nil,
-			wantErr: true,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			p := &Provider{
-				APPID:  tt.fields.APPID,
-				APPKey: tt.fields.APPKey,
-			}
-			got, err := p.SetRecords(tt.args.ctx, tt.args.zone, tt.args.records)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("SetRecords() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("SetRecords() got = %v, want %v", got, tt.want)
+// These exercise listRecordsCached directly (rather than through
+// GetRecords) so the assertions aren't muddied by findZone's own,
+// uncached /api/resolution/list hit.
+
+func TestProvider_listRecordsCached_cachesWithinTTL(t *testing.T) {
+	p, listCalls := newFakeGNAME(t)
+	p.CacheTTL = time.Minute
+
+	if _, err := p.listRecordsCached(context.Background(), testZone); err != nil {
+		t.Fatalf("listRecordsCached() #1 error = %v", err)
+	}
+	if _, err := p.listRecordsCached(context.Background(), testZone); err != nil {
+		t.Fatalf("listRecordsCached() #2 error = %v", err)
+	}
+
+	if got := atomic.LoadInt64(listCalls); got != 1 {
+		t.Fatalf("list calls = %d, want 1 (second call should have hit the cache)", got)
+	}
+}
+
+func TestProvider_listRecordsCached_refetchesAfterTTLExpires(t *testing.T) {
+	p, listCalls := newFakeGNAME(t)
+	p.CacheTTL = time.Millisecond
+
+	if _, err := p.listRecordsCached(context.Background(), testZone); err != nil {
+		t.Fatalf("listRecordsCached() #1 error = %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, err := p.listRecordsCached(context.Background(), testZone); err != nil {
+		t.Fatalf("listRecordsCached() #2 error = %v", err)
+	}
+
+	if got := atomic.LoadInt64(listCalls); got != 2 {
+		t.Fatalf("list calls = %d, want 2 (cache entry should have expired)", got)
+	}
+}
+
+func TestProvider_InvalidateCache_forcesRefetch(t *testing.T) {
+	p, listCalls := newFakeGNAME(t)
+	p.CacheTTL = time.Minute
+
+	if _, err := p.listRecordsCached(context.Background(), testZone); err != nil {
+		t.Fatalf("listRecordsCached() #1 error = %v", err)
+	}
+	p.InvalidateCache(testZone)
+	if _, err := p.listRecordsCached(context.Background(), testZone); err != nil {
+		t.Fatalf("listRecordsCached() #2 error = %v", err)
+	}
+
+	// InvalidateCache itself resolves the zone via findZone, which makes
+	// its own /api/resolution/list call, so the refetch below brings the
+	// total to 3 (initial fetch, findZone's lookup, forced refetch).
+	if got := atomic.LoadInt64(listCalls); got != 3 {
+		t.Fatalf("list calls = %d, want 3 (InvalidateCache should force a refetch)", got)
+	}
+}
+
+func TestProvider_listRecordsCached_coalescesConcurrentFetches(t *testing.T) {
+	p, listCalls := newFakeGNAME(t)
+	p.CacheTTL = time.Minute
+
+	const n = 10
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			if _, err := p.listRecordsCached(context.Background(), testZone); err != nil {
+				t.Errorf("listRecordsCached() error = %v", err)
 			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt64(listCalls); got != 1 {
+		t.Fatalf("list calls = %d, want 1 (concurrent fetches for the same zone should coalesce)", got)
+	}
+}
+
+func TestProvider_AppendRecords_honorsContextCancellation(t *testing.T) {
+	p, _ := newFakeGNAME(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := p.AppendRecords(ctx, testZone, []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "1.1.1.1", TTL: 60 * time.Second},
+	})
+	if err == nil {
+		t.Fatalf("AppendRecords() expected an error for an already-canceled context")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("AppendRecords() error = %v, want context.Canceled", err)
+	}
+}
+
+// TestProvider_AppendRecords_cancelsInFlightRequest verifies that canceling
+// the caller's context aborts an HTTP request that's already in flight,
+// rather than only being checked before the request is issued. The fake
+// /api/resolution/add handler holds the request open until the test
+// explicitly releases it, so the only thing that can make AppendRecords
+// return early is the client tearing down the request itself.
+func TestProvider_AppendRecords_cancelsInFlightRequest(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/resolution/list", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"code":1,"msg":"success","count":0,"data":[]}`)
+	})
+	mux.HandleFunc("/api/resolution/add", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	p := &Provider{APPID: testAppID, APPKey: testAppKey}
+	p.getClient().BaseURL = server.URL
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.AppendRecords(ctx, testZone, []libdns.Record{
+			libdns.RR{Name: "www", Type: "A", Data: "1.1.1.1", TTL: 60 * time.Second},
 		})
+		done <- err
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("AppendRecords() never issued the request")
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("AppendRecords() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("AppendRecords() did not return after its in-flight request was canceled")
 	}
 }